@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	hjson "github.com/hjson/hjson-go/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// HostConfig describes one recorder in a fleet config file. Port, User,
+// Path, Transport, Channels, StaleWarn and StaleErr fall back to the
+// corresponding CLI flag when left empty; Name, Hostname and Key have no
+// fallback and are used as given.
+type HostConfig struct {
+	Name      string   `yaml:"name" json:"name"`
+	Hostname  string   `yaml:"hostname" json:"hostname"`
+	Port      string   `yaml:"port" json:"port"`
+	User      string   `yaml:"user" json:"user"`
+	Key       string   `yaml:"key" json:"key"`
+	Path      string   `yaml:"path" json:"path"`
+	Transport string   `yaml:"transport" json:"transport"`
+	Channels  []string `yaml:"channels" json:"channels"`
+	StaleWarn string   `yaml:"stale_warn" json:"stale_warn"`
+	StaleErr  string   `yaml:"stale_err" json:"stale_err"`
+}
+
+// FleetConfig is the top-level shape of a -config file.
+type FleetConfig struct {
+	Workers int          `yaml:"workers" json:"workers"`
+	Hosts   []HostConfig `yaml:"hosts" json:"hosts"`
+}
+
+// loadFleetConfig reads a YAML or HJSON fleet config, picking the format by
+// file extension (.hjson vs. everything else defaulting to YAML).
+func loadFleetConfig(path string) (*FleetConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config %q: %w", path, err)
+	}
+
+	var cfg FleetConfig
+	if strings.EqualFold(filepath.Ext(path), ".hjson") {
+		var generic map[string]interface{}
+		if err := hjson.Unmarshal(raw, &generic); err != nil {
+			return nil, fmt.Errorf("invalid hjson config %q: %w", path, err)
+		}
+		jsonBytes, err := json.Marshal(generic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hjson config %q: %w", path, err)
+		}
+		if err := json.Unmarshal(jsonBytes, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid hjson config %q: %w", path, err)
+		}
+	} else if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid yaml config %q: %w", path, err)
+	}
+
+	if len(cfg.Hosts) == 0 {
+		return nil, fmt.Errorf("config %q defines no hosts", path)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	return &cfg, nil
+}
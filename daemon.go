@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// auditEntry is one line of the rolling audit log, recording what the
+// sensor saw at a given poll even when PRTG itself was never queried.
+type auditEntry struct {
+	Time            time.Time      `json:"time"`
+	ConnectionOK    bool           `json:"connection_ok"`
+	HostKeyMismatch bool           `json:"host_key_mismatch,omitempty"`
+	LatencyMillis   int64          `json:"latency_ms"`
+	Channels        map[string]int `json:"channels"`
+	Error           string         `json:"error,omitempty"`
+}
+
+// newAuditLogger builds the rotating writer the daemon appends audit
+// entries to.
+func newAuditLogger(path string, maxSizeMB, maxBackups, maxAgeDays int, compress bool) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMB,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAgeDays,
+		Compress:   compress,
+	}
+}
+
+// connectedLister is implemented by transports that can hold a connection
+// open across polls instead of reconnecting every time.
+type connectedLister interface {
+	SourceLister
+	Connect(ctx context.Context) error
+	Close() error
+}
+
+// runDaemon polls lister every interval, logging one auditEntry per poll to
+// auditLog, until the process is killed. If lister supports holding a
+// connection open, it connects once up front and reconnects after a failed
+// poll.
+func runDaemon(lister SourceLister, channels []string, parsers []FileNameParser, interval time.Duration, auditLog *lumberjack.Logger) {
+	defer auditLog.Close()
+
+	persistent, isPersistent := lister.(connectedLister)
+	if isPersistent {
+		if err := persistent.Connect(context.Background()); err != nil {
+			log.Printf("daemon: initial connect failed: %v", err)
+		}
+		defer persistent.Close()
+	}
+
+	encoder := json.NewEncoder(auditLog)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		entry := pollForAudit(lister, channels, parsers)
+		if err := encoder.Encode(entry); err != nil {
+			log.Printf("daemon: failed to write audit entry: %v", err)
+		}
+
+		if !entry.ConnectionOK && isPersistent {
+			if err := persistent.Connect(context.Background()); err != nil {
+				log.Printf("daemon: reconnect failed: %v", err)
+			}
+		}
+
+		<-ticker.C
+	}
+}
+
+// pollForAudit runs a single poll and reduces it to an auditEntry, never
+// returning an error itself so the daemon loop keeps running.
+func pollForAudit(lister SourceLister, channels []string, parsers []FileNameParser) auditEntry {
+	start := time.Now()
+	files, err := lister.List(context.Background())
+	latency := time.Since(start)
+
+	if err != nil {
+		var hostKeyErr *HostKeyMismatchError
+		return auditEntry{
+			Time:            start,
+			ConnectionOK:    false,
+			HostKeyMismatch: errors.As(err, &hostKeyErr),
+			LatencyMillis:   latency.Milliseconds(),
+			Error:           err.Error(),
+		}
+	}
+
+	channelMap, _ := monitorFolder(files, parsers)
+	fillMissingChannels(channelMap, channels)
+	channelResult, _ := summarizeChannels(channelMap)
+
+	return auditEntry{
+		Time:          start,
+		ConnectionOK:  true,
+		LatencyMillis: latency.Milliseconds(),
+		Channels:      channelResult,
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// fleetDefaults holds the settings shared by every host in a fleet config;
+// a HostConfig falls back to these whenever it doesn't override them.
+type fleetDefaults struct {
+	transport          string
+	username           string
+	port               string
+	path               string
+	channels           []string
+	parsers            []FileNameParser
+	knownHostsPath     string
+	hostKeyFingerprint string
+	insecure           bool
+	staleWarn          time.Duration
+	staleErr           time.Duration
+	gapAnomalyFactor   float64
+}
+
+// runFleet queries every host in cfg concurrently through a bounded worker
+// pool and merges their channels into a single PRTG document.
+func runFleet(cfg *FleetConfig, defaults fleetDefaults) Result {
+	jobs := make(chan HostConfig)
+	results := make(chan []Channel, len(cfg.Hosts))
+
+	workers := cfg.Workers
+	if workers > len(cfg.Hosts) {
+		workers = len(cfg.Hosts)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range jobs {
+				results <- pollHost(host, defaults)
+			}
+		}()
+	}
+
+	for _, host := range cfg.Hosts {
+		jobs <- host
+	}
+	close(jobs)
+
+	wg.Wait()
+	close(results)
+
+	prtg := Result{}
+	for channels := range results {
+		prtg.Result = append(prtg.Result, channels...)
+	}
+	return prtg
+}
+
+// pollHost polls a single fleet member and returns its channels, already
+// prefixed with the host's name.
+func pollHost(host HostConfig, defaults fleetDefaults) []Channel {
+	transport := orDefault(host.Transport, defaults.transport)
+	username := orDefault(host.User, defaults.username)
+	port := orDefault(host.Port, defaults.port)
+	path := orDefault(host.Path, defaults.path)
+	channels := host.Channels
+	if len(channels) == 0 {
+		channels = defaults.channels
+	}
+
+	lister, err := newSourceLister(transport, host.Hostname, username, host.Key, port, path, defaults.knownHostsPath, defaults.hostKeyFingerprint, defaults.insecure)
+	if err != nil {
+		return prefixChannels(host.Name, []Channel{connectionFailureChannel()})
+	}
+
+	files, err := lister.List(context.Background())
+	if err != nil {
+		var hostKeyErr *HostKeyMismatchError
+		if errors.As(err, &hostKeyErr) {
+			return prefixChannels(host.Name, hostKeyMismatchChannels())
+		}
+		return prefixChannels(host.Name, []Channel{connectionFailureChannel()})
+	}
+
+	channelMap, _ := monitorFolder(files, defaults.parsers)
+	fillMissingChannels(channelMap, channels)
+
+	staleWarn := parseDurationOrDefault(host.StaleWarn, defaults.staleWarn)
+	staleErr := parseDurationOrDefault(host.StaleErr, defaults.staleErr)
+
+	report := []Channel{connectionHealthyChannel()}
+	report = append(report, buildChannelReport(channelMap, staleWarn, staleErr, defaults.gapAnomalyFactor)...)
+	return prefixChannels(host.Name, report)
+}
+
+func orDefault(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+func parseDurationOrDefault(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+func prefixChannels(name string, channels []Channel) []Channel {
+	prefixed := make([]Channel, len(channels))
+	for i, c := range channels {
+		c.Channel = name + " " + c.Channel
+		prefixed[i] = c
+	}
+	return prefixed
+}
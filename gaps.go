@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// medianInterFileGap returns the median time between consecutive recordings
+// in timestamps. It needs at least two timestamps to define a gap.
+func medianInterFileGap(timestamps []time.Time) (time.Duration, bool) {
+	if len(timestamps) < 2 {
+		return 0, false
+	}
+
+	sorted := append([]time.Time(nil), timestamps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	gaps := make([]time.Duration, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		gaps = append(gaps, sorted[i].Sub(sorted[i-1]))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+
+	return gaps[len(gaps)/2], true
+}
+
+// newestInterFileGap returns the gap between the two most recently observed
+// recordings, i.e. the gap a stalled channel would currently be stuck on.
+func newestInterFileGap(timestamps []time.Time) (time.Duration, bool) {
+	if len(timestamps) < 2 {
+		return 0, false
+	}
+
+	sorted := append([]time.Time(nil), timestamps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	return sorted[len(sorted)-1].Sub(sorted[len(sorted)-2]), true
+}
+
+// latestRecording returns the newest timestamp observed for a channel. A
+// channel with no recordings gets the zero time, which deliberately trips
+// the stale-err threshold below instead of needing a special case.
+func latestRecording(timestamps []time.Time) time.Time {
+	var latest time.Time
+	for _, t := range timestamps {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// buildGapChannels emits, per channel, "X Minutes Since Last Rec" and "X Gap
+// Anomaly" (1 if the most recent inter-file gap exceeds anomalyFactor times
+// the channel's usual median gap). Together these catch a channel that dies
+// mid-day, before the total/today file counters would notice.
+func buildGapChannels(channelMap map[string]*ChannelCategory, now time.Time, staleWarn, staleErr time.Duration, anomalyFactor float64) []Channel {
+	var channels []Channel
+
+	for name, data := range channelMap {
+		minutesSince := now.Sub(latestRecording(data.Timestamps)).Minutes()
+		channels = append(channels, Channel{
+			Channel:         fmt.Sprintf("%s Minutes Since Last Rec", name),
+			LimitMode:       1,
+			LimitWarningMax: fmt.Sprintf("%d", int(staleWarn.Minutes())),
+			LimitErrorMax:   fmt.Sprintf("%d", int(staleErr.Minutes())),
+			LimitWarningMsg: "No recordings for a while",
+			LimitErrorMsg:   "Channel appears to have stopped recording",
+			Value:           fmt.Sprintf("%d", int(minutesSince)),
+			Unit:            "custom",
+			CustomUnit:      "min",
+		})
+
+		anomaly := 0
+		if median, ok := medianInterFileGap(data.Timestamps); ok {
+			if newest, ok := newestInterFileGap(data.Timestamps); ok && float64(newest) > anomalyFactor*float64(median) {
+				anomaly = 1
+			}
+		}
+		channels = append(channels, Channel{
+			Channel:    fmt.Sprintf("%s Gap Anomaly", name),
+			LimitMode:  0,
+			Value:      fmt.Sprintf("%d", anomaly),
+			Unit:       "custom",
+			CustomUnit: "bool",
+			Warning:    "1",
+		})
+	}
+
+	return channels
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMedianInterFileGap(t *testing.T) {
+	base := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	timestamps := []time.Time{
+		base,
+		base.Add(10 * time.Minute),
+		base.Add(20 * time.Minute),
+		base.Add(50 * time.Minute),
+	}
+
+	median, ok := medianInterFileGap(timestamps)
+	if !ok {
+		t.Fatal("expected a median gap with >= 2 timestamps")
+	}
+	if median != 10*time.Minute {
+		t.Errorf("median = %v, want 10m", median)
+	}
+
+	if _, ok := medianInterFileGap([]time.Time{base}); ok {
+		t.Error("expected no median gap with a single timestamp")
+	}
+}
+
+func TestBuildGapChannels_AnomalyDetected(t *testing.T) {
+	base := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	channelMap := map[string]*ChannelCategory{
+		"azadi": {
+			Name: "azadi",
+			Timestamps: []time.Time{
+				base,
+				base.Add(10 * time.Minute),
+				base.Add(20 * time.Minute),
+				base.Add(120 * time.Minute), // 100m gap, way past 3x the 10m median
+			},
+		},
+	}
+	now := base.Add(125 * time.Minute)
+
+	channels := buildGapChannels(channelMap, now, 30*time.Minute, 120*time.Minute, 3.0)
+
+	var anomaly, minutesSince *Channel
+	for i := range channels {
+		switch channels[i].Channel {
+		case "azadi Gap Anomaly":
+			anomaly = &channels[i]
+		case "azadi Minutes Since Last Rec":
+			minutesSince = &channels[i]
+		}
+	}
+
+	if anomaly == nil {
+		t.Fatal("expected an azadi Gap Anomaly channel")
+	}
+	if anomaly.Value != "1" {
+		t.Errorf("Gap Anomaly value = %q, want %q", anomaly.Value, "1")
+	}
+
+	if minutesSince == nil {
+		t.Fatal("expected an azadi Minutes Since Last Rec channel")
+	}
+	if minutesSince.Value != "5" {
+		t.Errorf("Minutes Since Last Rec value = %q, want %q", minutesSince.Value, "5")
+	}
+}
+
+func TestBuildGapChannels_NeverRecorded(t *testing.T) {
+	channelMap := map[string]*ChannelCategory{
+		"dead": {Name: "dead"},
+	}
+
+	channels := buildGapChannels(channelMap, time.Now(), 30*time.Minute, 120*time.Minute, 3.0)
+
+	for _, c := range channels {
+		if c.Channel == "dead Minutes Since Last Rec" {
+			return
+		}
+	}
+	t.Fatal("expected a Minutes Since Last Rec channel even for a channel with no recordings")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyMismatchError marks a host key verification failure distinctly from
+// other connection failures, so the sensor can report a dedicated "Host Key
+// Mismatch" PRTG channel instead of a generic connection failure.
+type HostKeyMismatchError struct {
+	Hostname string
+	Reason   error
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for %s: %v", e.Hostname, e.Reason)
+}
+
+func (e *HostKeyMismatchError) Unwrap() error { return e.Reason }
+
+// hostKeyVerifier wraps a HostKeyCallback and remembers the last
+// *HostKeyMismatchError it rejected. ssh.Dial wraps a HostKeyCallback's
+// error with fmt.Errorf("%v", err) during the handshake, which drops the
+// error chain, so errors.As can never recover a HostKeyMismatchError from
+// ssh.Dial's return value. Callers check Mismatch directly after a failed
+// dial instead of unwrapping the dial error.
+type hostKeyVerifier struct {
+	Callback ssh.HostKeyCallback
+	Mismatch *HostKeyMismatchError
+}
+
+func (v *hostKeyVerifier) verify(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	err := v.Callback(hostname, remote, key)
+	var mismatch *HostKeyMismatchError
+	if errors.As(err, &mismatch) {
+		v.Mismatch = mismatch
+	}
+	return err
+}
+
+// buildHostKeyVerifier builds the hostKeyVerifier used to dial the recorder:
+// -insecure skips verification, -host-key-fingerprint pins a single SHA256
+// fingerprint, otherwise known_hosts verification is strict.
+func buildHostKeyVerifier(knownHostsPath, fingerprint string, insecure bool) (*hostKeyVerifier, error) {
+	if insecure {
+		return &hostKeyVerifier{Callback: ssh.InsecureIgnoreHostKey()}, nil
+	}
+
+	if fingerprint != "" {
+		return &hostKeyVerifier{Callback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != fingerprint {
+				return &HostKeyMismatchError{Hostname: hostname, Reason: fmt.Errorf("got fingerprint %s, want %s", got, fingerprint)}
+			}
+			return nil
+		}}, nil
+	}
+
+	callback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load known_hosts %q: %w", knownHostsPath, err)
+	}
+
+	return &hostKeyVerifier{Callback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := callback(hostname, remote, key); err != nil {
+			return &HostKeyMismatchError{Hostname: hostname, Reason: err}
+		}
+		return nil
+	}}, nil
+}
+
+// defaultKnownHostsPath returns ~/.ssh/known_hosts, or "" if the home
+// directory can't be resolved.
+func defaultKnownHostsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
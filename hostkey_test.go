@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSSHServer starts a minimal in-process SSH server that completes
+// the TCP accept and hands the connection to a real ssh.ServerConn
+// handshake, so a test can dial it with a real ssh.ClientConfig.
+func startTestSSHServer(t *testing.T) string {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("build host key signer: %v", err)
+	}
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ssh.NewServerConn(conn, config)
+	}()
+
+	return listener.Addr().String()
+}
+
+// This reproduces the real ssh.Dial handshake end to end: ssh.Dial wraps a
+// HostKeyCallback's error with fmt.Errorf("%v", err), which used to make
+// errors.As(err, &HostKeyMismatchError{}) fail on the dial error. dial()
+// must recover the mismatch via hostKeyVerifier instead of unwrapping it.
+func TestSSHConnectOptionsDial_HostKeyMismatchSurvivesSSHDial(t *testing.T) {
+	addr := startTestSSHServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("split host port: %v", err)
+	}
+
+	verifier := &hostKeyVerifier{
+		Callback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return &HostKeyMismatchError{Hostname: hostname, Reason: fmt.Errorf("got fingerprint %s, want bogus", ssh.FingerprintSHA256(key))}
+		},
+	}
+
+	opts := sshConnectOptions{
+		hostname:        host,
+		port:            port,
+		username:        "test",
+		hostKeyVerifier: verifier,
+		config: &ssh.ClientConfig{
+			User:            "test",
+			HostKeyCallback: verifier.verify,
+		},
+	}
+
+	if _, err := opts.dial(); err == nil {
+		t.Fatal("expected dial to fail due to host key mismatch")
+	} else {
+		var mismatch *HostKeyMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("errors.As could not recover *HostKeyMismatchError from dial error: %v", err)
+		}
+	}
+}
+
+func TestHostKeyVerifier_NoMismatchOnSuccess(t *testing.T) {
+	addr := startTestSSHServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("split host port: %v", err)
+	}
+
+	verifier := &hostKeyVerifier{Callback: ssh.InsecureIgnoreHostKey()}
+	opts := sshConnectOptions{
+		hostname:        host,
+		port:            port,
+		username:        "test",
+		hostKeyVerifier: verifier,
+		config: &ssh.ClientConfig{
+			User:            "test",
+			HostKeyCallback: verifier.verify,
+		},
+	}
+
+	client, err := opts.dial()
+	if err != nil {
+		t.Fatalf("unexpected dial error: %v", err)
+	}
+	client.Close()
+
+	if verifier.Mismatch != nil {
+		t.Errorf("expected no mismatch recorded, got %v", verifier.Mismatch)
+	}
+}
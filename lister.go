@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// FileInfo is the transport-agnostic view of a recorded file that the rest
+// of the sensor (monitorFolder, parseFileNameWithParsers) operates on.
+type FileInfo struct {
+	Name    string
+	ModTime time.Time
+	Size    int64
+}
+
+// SourceLister enumerates the files present in the recorder's output folder,
+// hiding how that enumeration happens (ssh, sftp, a local directory walk).
+type SourceLister interface {
+	List(ctx context.Context) ([]FileInfo, error)
+}
+
+// sshConnectOptions bundles the dial parameters shared by the ssh- and
+// sftp-based listers.
+type sshConnectOptions struct {
+	hostname        string
+	port            string
+	username        string
+	config          *ssh.ClientConfig
+	hostKeyVerifier *hostKeyVerifier
+}
+
+func (o sshConnectOptions) dial() (*ssh.Client, error) {
+	if o.hostKeyVerifier != nil {
+		o.hostKeyVerifier.Mismatch = nil
+	}
+	serverAddress := fmt.Sprintf("%s:%s", o.hostname, o.port)
+	conn, err := ssh.Dial("tcp", serverAddress, o.config)
+	if err != nil && o.hostKeyVerifier != nil && o.hostKeyVerifier.Mismatch != nil {
+		return nil, o.hostKeyVerifier.Mismatch
+	}
+	return conn, err
+}
+
+// sshLsLister is the original transport: it runs `ls -lha <path>` over SSH
+// and parses the listing.
+type sshLsLister struct {
+	opts sshConnectOptions
+	path string
+	conn *ssh.Client // non-nil once Connect has been called; reused across List calls
+}
+
+func newSSHLsLister(opts sshConnectOptions, path string) *sshLsLister {
+	return &sshLsLister{opts: opts, path: path}
+}
+
+// Connect dials and keeps the connection open for subsequent List calls.
+// Calling it again closes the old connection first so it doesn't leak.
+func (l *sshLsLister) Connect(ctx context.Context) error {
+	conn, err := l.opts.dial()
+	if err != nil {
+		return err
+	}
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.conn = conn
+	return nil
+}
+
+func (l *sshLsLister) Close() error {
+	if l.conn == nil {
+		return nil
+	}
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
+
+func (l *sshLsLister) List(ctx context.Context) ([]FileInfo, error) {
+	conn := l.conn
+	if conn == nil {
+		dialed, err := l.opts.dial()
+		if err != nil {
+			return nil, err
+		}
+		defer dialed.Close()
+		conn = dialed
+	}
+
+	session, err := conn.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var stdoutBuf bytes.Buffer
+	session.Stdout = &stdoutBuf
+	if err := session.Run("ls -lha " + l.path); err != nil {
+		return nil, err
+	}
+
+	return parseLsOutput(stdoutBuf.String()), nil
+}
+
+// sftpLister lists the folder over SFTP, getting real mtime/size from the
+// protocol instead of scraping `ls` columns.
+type sftpLister struct {
+	opts sshConnectOptions
+	path string
+	conn *ssh.Client // non-nil once Connect has been called; reused across List calls
+}
+
+func newSFTPLister(opts sshConnectOptions, path string) *sftpLister {
+	return &sftpLister{opts: opts, path: path}
+}
+
+// Connect dials and keeps the connection open for subsequent List calls.
+// Calling it again closes the old connection first so it doesn't leak.
+func (l *sftpLister) Connect(ctx context.Context) error {
+	conn, err := l.opts.dial()
+	if err != nil {
+		return err
+	}
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.conn = conn
+	return nil
+}
+
+func (l *sftpLister) Close() error {
+	if l.conn == nil {
+		return nil
+	}
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
+
+func (l *sftpLister) List(ctx context.Context) ([]FileInfo, error) {
+	conn := l.conn
+	if conn == nil {
+		dialed, err := l.opts.dial()
+		if err != nil {
+			return nil, err
+		}
+		defer dialed.Close()
+		conn = dialed
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	entries, err := client.ReadDir(l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		files = append(files, FileInfo{
+			Name:    entry.Name(),
+			ModTime: entry.ModTime(),
+			Size:    entry.Size(),
+		})
+	}
+	return files, nil
+}
+
+// localLister walks a local directory, for on-box installs where the sensor
+// runs directly on the recorder.
+type localLister struct {
+	path string
+}
+
+func newLocalLister(path string) *localLister {
+	return &localLister{path: path}
+}
+
+func (l *localLister) List(ctx context.Context) ([]FileInfo, error) {
+	entries, err := os.ReadDir(l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{
+			Name:    entry.Name(),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+	return files, nil
+}
+
+// newSourceLister builds the SourceLister selected by -transport, validating
+// the arguments each transport actually needs.
+func newSourceLister(transport, hostname, username, key, port, path, knownHostsPath, hostKeyFingerprint string, insecure bool) (SourceLister, error) {
+	switch transport {
+	case "local":
+		return newLocalLister(path), nil
+	case "ssh", "sftp":
+		if hostname == "" || key == "" {
+			return nil, fmt.Errorf("please supply required arguments: -hostname and -key")
+		}
+
+		keyBytes, err := ioutil.ReadFile(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read private key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse private key: %w", err)
+		}
+
+		verifier, err := buildHostKeyVerifier(knownHostsPath, hostKeyFingerprint, insecure)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := sshConnectOptions{
+			hostname:        hostname,
+			port:            port,
+			username:        username,
+			hostKeyVerifier: verifier,
+			config: &ssh.ClientConfig{
+				User: username,
+				Auth: []ssh.AuthMethod{
+					ssh.PublicKeys(signer),
+				},
+				HostKeyCallback: verifier.verify,
+			},
+		}
+
+		if transport == "sftp" {
+			return newSFTPLister(opts, path), nil
+		}
+		return newSSHLsLister(opts, path), nil
+	case "rsyncd":
+		return nil, fmt.Errorf("transport %q is not implemented: this sensor supports ssh, sftp, and local only", transport)
+	default:
+		return nil, fmt.Errorf("unknown transport %q: expected ssh, sftp, or local", transport)
+	}
+}
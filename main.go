@@ -1,16 +1,14 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"strings"
 	"time"
-
-	"golang.org/x/crypto/ssh"
 )
 
 // PRTG structure for XML output
@@ -45,71 +43,48 @@ type ChannelData struct {
 
 // Structure to hold channel information
 type ChannelCategory struct {
-	Name    string
-	Records map[string]int // date to file count mapping
+	Name       string
+	Records    map[string]int // date to file count mapping
+	Timestamps []time.Time    // full recording timestamps, used for gap/staleness detection
 }
 
-// Function to parse the file name
-func parseFileName(fileName string) (channel string, date string, err error) {
-	// Example fileName: azadi-01-Jul-24-02:34:07.audio.m4a
-
-	// Remove the file extension
-	nameParts := strings.Split(fileName, ".")
-	if len(nameParts) < 2 {
-		return "", "", fmt.Errorf("invalid file format: %s", fileName)
-	}
-
-	// Split the name part by hyphen to extract the channel name and date-time
-	parts := strings.Split(nameParts[0], "-")
-	if len(parts) < 5 {
-		return "", "", fmt.Errorf("invalid file format: %s", fileName)
-	}
-
-	channel = parts[0]
-	day := parts[1]
-	month := parts[2]
-	year := parts[3]
-	timePart := parts[4]
+// Function to monitor and categorize files
+func monitorFolder(files []FileInfo, parsers []FileNameParser) (map[string]*ChannelCategory, error) {
+	channelMap := make(map[string]*ChannelCategory)
 
-	// Combine date and time
-	dateString := fmt.Sprintf("%s-%s-%s %s", day, month, year, timePart)
+	for _, file := range files {
+		channel, timestamp, err := parseFileNameWithParsers(file.Name, parsers)
+		if err != nil {
+			//log.Println("Skipping file:", file.Name, "Error:", err)
+			continue
+		}
 
-	// Parse the date string into a time.Time object
-	parsedDate, err := time.Parse("02-Jan-06 15:04:05", dateString)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid date format in file: %s", fileName)
+		if _, exists := channelMap[channel]; !exists {
+			channelMap[channel] = &ChannelCategory{
+				Name:    channel,
+				Records: make(map[string]int),
+			}
+		}
+		date := timestamp.Format("2006-01-02")
+		channelMap[channel].Records[date]++
+		channelMap[channel].Timestamps = append(channelMap[channel].Timestamps, timestamp)
 	}
-
-	date = parsedDate.Format("2006-01-02") // Format date as YYYY-MM-DD
-	return channel, date, nil
+	return channelMap, nil
 }
 
-// Function to monitor and categorize files
-func monitorFolder(output string) (map[string]*ChannelCategory, error) {
-	lines := strings.Split(output, "\n")
-
-	channelMap := make(map[string]*ChannelCategory)
-
-	for _, line := range lines {
+// parseLsOutput turns the output of `ls -lha <path>` into FileInfo entries.
+// It only has the file name to go on (ls's mtime column is low-resolution
+// and locale-dependent), so ModTime/Size are left zero; callers that need
+// real timestamps should use the sftp or local transport instead.
+func parseLsOutput(output string) []FileInfo {
+	var files []FileInfo
+	for _, line := range strings.Split(output, "\n") {
 		fields := strings.Fields(line)
 		if len(fields) > 8 {
-			fileName := fields[8]
-			channel, date, err := parseFileName(fileName)
-			if err != nil {
-				//log.Println("Skipping file:", fileName, "Error:", err)
-				continue
-			}
-
-			if _, exists := channelMap[channel]; !exists {
-				channelMap[channel] = &ChannelCategory{
-					Name:    channel,
-					Records: make(map[string]int),
-				}
-			}
-			channelMap[channel].Records[date]++
+			files = append(files, FileInfo{Name: fields[8]})
 		}
 	}
-	return channelMap, nil
+	return files
 }
 
 func compareDates(time1 string, t2 time.Time) bool {
@@ -120,6 +95,38 @@ func compareDates(time1 string, t2 time.Time) bool {
 	return t1.Year() == t2.Year() && t1.Month() == t2.Month() && t1.Day() == t2.Day()
 }
 
+// fillMissingChannels ensures every configured channel has an entry in
+// channelMap, even if no files were observed for it, so a dead channel
+// still shows up in the report instead of silently disappearing.
+func fillMissingChannels(channelMap map[string]*ChannelCategory, channels []string) {
+	for _, channel := range channels {
+		if _, exists := channelMap[channel]; !exists {
+			channelMap[channel] = &ChannelCategory{
+				Name:    channel,
+				Records: make(map[string]int),
+			}
+		}
+	}
+}
+
+// summarizeChannels reduces a channelMap to total-files-ever-seen and
+// files-recorded-today counts, keyed by channel name.
+func summarizeChannels(channelMap map[string]*ChannelCategory) (total map[string]int, today map[string]int) {
+	total = make(map[string]int)
+	today = make(map[string]int)
+	for channel, data := range channelMap {
+		today[channel] = 0
+		total[channel] = 0
+		for date, count := range data.Records {
+			if compareDates(date, time.Now()) {
+				today[channel] = count
+			}
+			total[channel] += count
+		}
+	}
+	return total, today
+}
+
 func main() {
 	var name string
 	var hostname string
@@ -128,135 +135,124 @@ func main() {
 	var port string
 	var path string
 	var channelNames string
+	var transport string
+	var daemon bool
+	var interval time.Duration
+	var auditLogPath string
+	var auditMaxSizeMB int
+	var auditMaxBackups int
+	var auditMaxAgeDays int
+	var auditCompress bool
+	var staleWarn time.Duration
+	var staleErr time.Duration
+	var gapAnomalyFactor float64
+	var namePatterns string
+	var namePatternLayouts string
+	var nameLayouts string
+	var knownHostsPath string
+	var hostKeyFingerprint string
+	var insecure bool
+	var configPath string
 
 	flag.StringVar(&name, "name", "node-name", "Node name. Default is node-name.")
-	flag.StringVar(&hostname, "hostname", "", "Hostname. Required.")
+	flag.StringVar(&hostname, "hostname", "", "Hostname. Required for ssh/sftp transports.")
 	flag.StringVar(&username, "user", "root", "Username. Default is root.")
-	flag.StringVar(&key, "key", "", "SSH Private Key. Required.")
+	flag.StringVar(&key, "key", "", "SSH Private Key. Required for ssh/sftp transports.")
 	flag.StringVar(&port, "port", "22", "SSH Port. Default is 22.")
 	flag.StringVar(&path, "path", "/var/rec", "Device Index. Default is /var/rec.")
 	flag.StringVar(&channelNames, "chan", "itn,azadi,voa,pars,bbc,one", "Channels Names Camma seperate. Default is itn,azadi,voa,pars,bbc,one")
+	flag.StringVar(&transport, "transport", "ssh", "How to enumerate recorded files: ssh|sftp|local. rsyncd is not implemented. Default is ssh.")
+	flag.BoolVar(&daemon, "daemon", false, "Run as a long-running daemon instead of the default one-shot PRTG check.")
+	flag.DurationVar(&interval, "interval", 60*time.Second, "Poll interval in daemon mode. Default is 60s.")
+	flag.StringVar(&auditLogPath, "audit-log", "gvb-sensor-audit.log", "Rotating audit log path used in daemon mode.")
+	flag.IntVar(&auditMaxSizeMB, "audit-max-size", 100, "Max audit log size in megabytes before rotation.")
+	flag.IntVar(&auditMaxBackups, "audit-max-backups", 7, "Max number of rotated audit log files to keep.")
+	flag.IntVar(&auditMaxAgeDays, "audit-max-age", 28, "Max age in days to retain rotated audit log files.")
+	flag.BoolVar(&auditCompress, "audit-compress", true, "Gzip rotated audit log files.")
+	flag.DurationVar(&staleWarn, "stale-warn", 30*time.Minute, "Warn when a channel has had no recordings for this long. Default is 30m.")
+	flag.DurationVar(&staleErr, "stale-err", 120*time.Minute, "Error when a channel has had no recordings for this long. Default is 120m.")
+	flag.Float64Var(&gapAnomalyFactor, "gap-anomaly-factor", 3.0, "Flag a gap anomaly when the newest inter-file gap exceeds this multiple of the channel's median gap.")
+	flag.StringVar(&namePatterns, "name-pattern", "", "Comma-separated regexp(s) with named groups (channel, timestamp or day/month/year/time) to parse recording file names. Tried before -name-layout and the built-in format.")
+	flag.StringVar(&namePatternLayouts, "name-pattern-layout", "", "Comma-separated Go time layout(s), one per -name-pattern entry by position, for parsing that pattern's timestamp/day-month-year-time groups. A pattern with no corresponding entry falls back to 02-Jan-06 15:04:05.")
+	flag.StringVar(&nameLayouts, "name-layout", "", "Comma-separated strftime-style layout(s) for the channel-<timestamp>.ext convention. Tried before the built-in format.")
+	flag.StringVar(&knownHostsPath, "known-hosts", defaultKnownHostsPath(), "known_hosts file used to verify the SSH host key. Default is ~/.ssh/known_hosts.")
+	flag.StringVar(&hostKeyFingerprint, "host-key-fingerprint", "", "Pin a single SHA256 host key fingerprint instead of checking -known-hosts.")
+	flag.BoolVar(&insecure, "insecure", false, "Skip SSH host key verification entirely. Insecure; only for use when no known_hosts or fingerprint is available.")
+	flag.StringVar(&configPath, "config", "", "YAML or HJSON fleet config listing multiple hosts to query concurrently, merged into one PRTG document. Overrides single-host mode.")
 
 	channels := strings.Split(channelNames, ",")
 
 	flag.Parse()
 
-	if hostname == "" || key == "" {
-		log.Fatal("Please supply required arguments.")
-	}
-
-	keyBytes, err := ioutil.ReadFile(key)
+	parsers, err := buildNameParsers(namePatterns, namePatternLayouts, nameLayouts, "02-Jan-06 15:04:05")
 	if err != nil {
-		log.Fatalf("Unable to read private key: %v", err)
-	}
-	signer, err := ssh.ParsePrivateKey(keyBytes)
-	if err != nil {
-		return
-	}
-	// SSH connection configuration
-	sshConfig := &ssh.ClientConfig{
-		User: username,
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		log.Fatal(err)
 	}
 
-	// Server address
-	serverAddress := fmt.Sprintf("%s:%s", hostname, port)
+	if configPath != "" {
+		cfg, err := loadFleetConfig(configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		prtg := runFleet(cfg, fleetDefaults{
+			transport:          transport,
+			username:           username,
+			port:               port,
+			path:               path,
+			channels:           channels,
+			parsers:            parsers,
+			knownHostsPath:     knownHostsPath,
+			hostKeyFingerprint: hostKeyFingerprint,
+			insecure:           insecure,
+			staleWarn:          staleWarn,
+			staleErr:           staleErr,
+			gapAnomalyFactor:   gapAnomalyFactor,
+		})
 
-	// Connect to the server
-	conn, err := ssh.Dial("tcp", serverAddress, sshConfig)
-	if err != nil {
-		//fmt.Printf("Failed to dial: %v\n", err)
-		printConnectionFailure()
+		output, err := xml.MarshalIndent(prtg, "", "  ")
+		if err != nil {
+			fmt.Println("Error generating XML:", err)
+			return
+		}
+		fmt.Println(string(output))
 		return
 	}
-	defer conn.Close()
 
-	// Create a session
-	session, err := conn.NewSession()
+	lister, err := newSourceLister(transport, hostname, username, key, port, path, knownHostsPath, hostKeyFingerprint, insecure)
 	if err != nil {
-		//fmt.Printf("Failed to create session: %v\n", err)
-		printConnectionFailure()
+		log.Fatal(err)
+	}
+
+	if daemon {
+		auditLog := newAuditLogger(auditLogPath, auditMaxSizeMB, auditMaxBackups, auditMaxAgeDays, auditCompress)
+		runDaemon(lister, channels, parsers, interval, auditLog)
 		return
 	}
-	defer session.Close()
-
-	// Run the command and capture the output
-	command := "ls -lha " + path
-	var stdoutBuf bytes.Buffer
-	session.Stdout = &stdoutBuf
-	if err := session.Run(command); err != nil {
-		//fmt.Printf("Failed to run: %v\n", err)
-		printConnectionFailure()
+
+	files, err := lister.List(context.Background())
+	if err != nil {
+		//fmt.Printf("Failed to list files: %v\n", err)
+		var hostKeyErr *HostKeyMismatchError
+		if errors.As(err, &hostKeyErr) {
+			printHostKeyMismatch()
+		} else {
+			printConnectionFailure()
+		}
 		return
 	}
 
-	channelMap, err := monitorFolder(stdoutBuf.String())
+	channelMap, err := monitorFolder(files, parsers)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	for index := range channels {
-		exist := false
-		for channel, _ := range channelMap {
-			if channels[index] == channel {
-				exist = true
-			}
-		}
-		if !exist {
-			channelMap[channels[index]] = &ChannelCategory{
-				Name:    channels[index],
-				Records: make(map[string]int),
-			}
-		}
-	}
+	fillMissingChannels(channelMap, channels)
 	//displayResults(channelMap)
 
-	channelResult := make(map[string]int)
-	channelTodayResult := make(map[string]int)
-	for channel, data := range channelMap {
-		channelTodayResult[channel] = 0
-		channelResult[channel] = 0
-		for date, count := range data.Records {
-			if compareDates(date, time.Now()) {
-				channelTodayResult[channel] = count
-			}
-			channelResult[channel] += count
-		}
-	}
-
 	prtg := Result{}
-	prtg.Result = append(prtg.Result, Channel{
-		Channel:     "Connection Health",
-		Value:       "0",
-		Unit:        "Interger",
-		LimitMode:   0,
-		ValueLookup: "prtg.customlookups.gvb-sensor.timeout",
-		Warning:     "1",
-	})
-
-	for channel, count := range channelResult {
-		prtg.Result = append(prtg.Result, Channel{
-			Channel:         fmt.Sprintf("%s Total files", channel),
-			LimitMode:       1,
-			LimitErrorMax:   "70",
-			LimitWarningMax: "50",
-			LimitErrorMsg:   "Too much file are stored",
-			LimitWarningMsg: "Transfering files failed",
-			Value:           fmt.Sprintf("%d", count),
-			Unit:            "custom",
-			CustomUnit:      "files",
-		})
-		prtg.Result = append(prtg.Result, Channel{
-			Channel:    fmt.Sprintf("%s Today Rec", channel),
-			LimitMode:  0,
-			Value:      fmt.Sprintf("%d", channelTodayResult[channel]),
-			Unit:       "custom",
-			CustomUnit: "files",
-		})
-	}
+	prtg.Result = append(prtg.Result, connectionHealthyChannel())
+	prtg.Result = append(prtg.Result, buildChannelReport(channelMap, staleWarn, staleErr, gapAnomalyFactor)...)
 
 	output, err := xml.MarshalIndent(prtg, "", "  ")
 	if err != nil {
@@ -269,18 +265,18 @@ func main() {
 }
 
 func printConnectionFailure() {
-	prtgResult := Result{
-		Result: []Channel{
-			{
-				Channel:     "Connection Health",
-				Value:       "1",
-				Unit:        "Interger",
-				LimitMode:   0,
-				ValueLookup: "prtg.customlookups.gvb-sensor.timeout",
-				Warning:     "1",
-			},
-		},
+	prtgResult := Result{Result: []Channel{connectionFailureChannel()}}
+	output, err := xml.MarshalIndent(prtgResult, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshalling XML: %s", err)
 	}
+
+	// Print XML
+	fmt.Println(xml.Header + string(output))
+}
+
+func printHostKeyMismatch() {
+	prtgResult := Result{Result: hostKeyMismatchChannels()}
 	output, err := xml.MarshalIndent(prtgResult, "", "  ")
 	if err != nil {
 		log.Fatalf("Error marshalling XML: %s", err)
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonitorFolder(t *testing.T) {
+	files := []FileInfo{
+		{Name: "azadi-01-Jul-24-02:34:07.audio.m4a"},
+		{Name: "azadi-02-Jul-24-02:34:07.audio.m4a"},
+		{Name: "bbc-01-Jul-24-10:00:00.audio.m4a"},
+		{Name: "not-a-recording.txt"},
+	}
+
+	channelMap, err := monitorFolder(files, []FileNameParser{defaultNameParser{}})
+	if err != nil {
+		t.Fatalf("monitorFolder returned error: %v", err)
+	}
+
+	if len(channelMap) != 2 {
+		t.Fatalf("expected 2 channels, got %d: %v", len(channelMap), channelMap)
+	}
+
+	azadi, ok := channelMap["azadi"]
+	if !ok {
+		t.Fatalf("expected channel %q to exist", "azadi")
+	}
+	if got := azadi.Records["2024-07-01"]; got != 1 {
+		t.Errorf("azadi 2024-07-01 count = %d, want 1", got)
+	}
+	if got := azadi.Records["2024-07-02"]; got != 1 {
+		t.Errorf("azadi 2024-07-02 count = %d, want 1", got)
+	}
+	if len(azadi.Timestamps) != 2 {
+		t.Errorf("azadi timestamps = %d, want 2", len(azadi.Timestamps))
+	}
+
+	if _, ok := channelMap["not-a-recording.txt"]; ok {
+		t.Errorf("unparseable file should not create a channel")
+	}
+}
+
+func TestSummarizeChannels(t *testing.T) {
+	now := time.Now()
+	channelMap := map[string]*ChannelCategory{
+		"azadi": {
+			Name: "azadi",
+			Records: map[string]int{
+				now.Format("2006-01-02"): 3,
+				"2000-01-01":             5,
+			},
+		},
+	}
+
+	total, today := summarizeChannels(channelMap)
+	if total["azadi"] != 8 {
+		t.Errorf("total = %d, want 8", total["azadi"])
+	}
+	if today["azadi"] != 3 {
+		t.Errorf("today = %d, want 3", today["azadi"])
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FileNameParser extracts a channel name and recording timestamp from a
+// recorded file's name. The sensor tries a configurable list of these in
+// order and uses the first one that understands a given name.
+type FileNameParser interface {
+	Parse(fileName string) (channel string, timestamp time.Time, err error)
+}
+
+// defaultNameParser implements the original, hardcoded convention:
+// channel-DD-Mon-YY-HH:MM:SS.ext, e.g. azadi-01-Jul-24-02:34:07.audio.m4a.
+type defaultNameParser struct{}
+
+func (defaultNameParser) Parse(fileName string) (channel string, timestamp time.Time, err error) {
+	nameParts := strings.Split(fileName, ".")
+	if len(nameParts) < 2 {
+		return "", time.Time{}, fmt.Errorf("invalid file format: %s", fileName)
+	}
+
+	parts := strings.Split(nameParts[0], "-")
+	if len(parts) < 5 {
+		return "", time.Time{}, fmt.Errorf("invalid file format: %s", fileName)
+	}
+
+	channel = parts[0]
+	day := parts[1]
+	month := parts[2]
+	year := parts[3]
+	timePart := parts[4]
+
+	dateString := fmt.Sprintf("%s-%s-%s %s", day, month, year, timePart)
+
+	parsedDate, err := time.Parse("02-Jan-06 15:04:05", dateString)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid date format in file: %s", fileName)
+	}
+
+	return channel, parsedDate, nil
+}
+
+// regexNameParser extracts channel/timestamp using a user-supplied regexp
+// with named groups: a "channel" group plus either a single "timestamp"
+// group or the "day"/"month"/"year"/"time" groups.
+type regexNameParser struct {
+	re     *regexp.Regexp
+	layout string
+}
+
+func newRegexNameParser(pattern, layout string) (*regexNameParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -name-pattern %q: %w", pattern, err)
+	}
+	if layout == "" {
+		layout = "02-Jan-06 15:04:05"
+	}
+	return &regexNameParser{re: re, layout: layout}, nil
+}
+
+func (p *regexNameParser) Parse(fileName string) (channel string, timestamp time.Time, err error) {
+	match := p.re.FindStringSubmatch(fileName)
+	if match == nil {
+		return "", time.Time{}, fmt.Errorf("name-pattern did not match: %s", fileName)
+	}
+
+	groups := make(map[string]string, len(match))
+	for i, groupName := range p.re.SubexpNames() {
+		if i == 0 || groupName == "" {
+			continue
+		}
+		groups[groupName] = match[i]
+	}
+
+	channel = groups["channel"]
+	if channel == "" {
+		return "", time.Time{}, fmt.Errorf("name-pattern has no \"channel\" group: %s", fileName)
+	}
+
+	if ts, ok := groups["timestamp"]; ok {
+		parsed, err := time.Parse(p.layout, ts)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid timestamp in file: %s", fileName)
+		}
+		return channel, parsed, nil
+	}
+
+	day, hasDay := groups["day"]
+	month, hasMonth := groups["month"]
+	year, hasYear := groups["year"]
+	timePart, hasTime := groups["time"]
+	if !hasDay || !hasMonth || !hasYear || !hasTime {
+		return "", time.Time{}, fmt.Errorf("name-pattern must capture either \"timestamp\" or day/month/year/time: %s", fileName)
+	}
+
+	dateString := fmt.Sprintf("%s-%s-%s %s", day, month, year, timePart)
+	parsed, err := time.Parse(p.layout, dateString)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid date format in file: %s", fileName)
+	}
+
+	return channel, parsed, nil
+}
+
+// layoutNameParser handles recorders that keep the original
+// "<channel>-<timestamp>.<ext>" shape but use a different timestamp format.
+type layoutNameParser struct {
+	goLayout string
+}
+
+func newLayoutNameParser(strftimeLayout string) *layoutNameParser {
+	return &layoutNameParser{goLayout: strftimeToGoLayout(strftimeLayout)}
+}
+
+func (p *layoutNameParser) Parse(fileName string) (channel string, timestamp time.Time, err error) {
+	nameParts := strings.SplitN(fileName, ".", 2)
+	if len(nameParts) < 2 {
+		return "", time.Time{}, fmt.Errorf("invalid file format: %s", fileName)
+	}
+
+	idx := strings.Index(nameParts[0], "-")
+	if idx < 0 {
+		return "", time.Time{}, fmt.Errorf("invalid file format: %s", fileName)
+	}
+	channel = nameParts[0][:idx]
+	rest := nameParts[0][idx+1:]
+
+	parsed, err := time.Parse(p.goLayout, rest)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid date format in file: %s", fileName)
+	}
+
+	return channel, parsed, nil
+}
+
+// strftimeToGoLayout translates the handful of strftime directives a
+// recorder's filename convention uses into a Go reference time layout.
+func strftimeToGoLayout(strftime string) string {
+	replacer := strings.NewReplacer(
+		"%Y", "2006",
+		"%y", "06",
+		"%m", "01",
+		"%b", "Jan",
+		"%B", "January",
+		"%d", "02",
+		"%H", "15",
+		"%M", "04",
+		"%S", "05",
+	)
+	return replacer.Replace(strftime)
+}
+
+// buildNameParsers assembles the ordered list of parsers to try from the
+// comma-separated -name-pattern / -name-layout flags, always falling back
+// to the original hardcoded convention. namePatternLayouts pairs with
+// namePatterns by position (the i-th layout applies to the i-th pattern's
+// "timestamp"/day-month-year-time groups); a pattern with no corresponding
+// layout entry uses defaultLayout.
+func buildNameParsers(namePatterns, namePatternLayouts, nameLayouts, defaultLayout string) ([]FileNameParser, error) {
+	var parsers []FileNameParser
+
+	patterns := splitNonEmpty(namePatterns)
+	patternLayouts := splitNonEmpty(namePatternLayouts)
+	for i, pattern := range patterns {
+		layout := defaultLayout
+		if i < len(patternLayouts) {
+			layout = patternLayouts[i]
+		}
+		parser, err := newRegexNameParser(pattern, layout)
+		if err != nil {
+			return nil, err
+		}
+		parsers = append(parsers, parser)
+	}
+
+	for _, layout := range splitNonEmpty(nameLayouts) {
+		parsers = append(parsers, newLayoutNameParser(layout))
+	}
+
+	parsers = append(parsers, defaultNameParser{})
+	return parsers, nil
+}
+
+func splitNonEmpty(commaSeparated string) []string {
+	if commaSeparated == "" {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(commaSeparated, ",") {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseFileNameWithParsers tries each parser in order and returns the
+// first successful match, or the last error if none of them match.
+func parseFileNameWithParsers(fileName string, parsers []FileNameParser) (channel string, timestamp time.Time, err error) {
+	for _, parser := range parsers {
+		channel, timestamp, err = parser.Parse(fileName)
+		if err == nil {
+			return channel, timestamp, nil
+		}
+	}
+	return "", time.Time{}, err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFileNameWithParsers_DefaultFormat(t *testing.T) {
+	parsers := []FileNameParser{defaultNameParser{}}
+
+	channel, timestamp, err := parseFileNameWithParsers("azadi-01-Jul-24-02:34:07.audio.m4a", parsers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if channel != "azadi" {
+		t.Errorf("channel = %q, want %q", channel, "azadi")
+	}
+	want := time.Date(2024, time.July, 1, 2, 34, 7, 0, time.UTC)
+	if !timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", timestamp, want)
+	}
+}
+
+func TestParseFileNameWithParsers_RegexTimestampGroup(t *testing.T) {
+	pattern := `^(?P<channel>[a-z]+)_(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2})\..+$`
+	parsers, err := buildNameParsers(pattern, "2006-01-02T15-04-05", "", "02-Jan-06 15:04:05")
+	if err != nil {
+		t.Fatalf("buildNameParsers error: %v", err)
+	}
+
+	channel, timestamp, err := parseFileNameWithParsers("voa_2024-07-01T02-34-07.wav", parsers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if channel != "voa" {
+		t.Errorf("channel = %q, want %q", channel, "voa")
+	}
+	want := time.Date(2024, time.July, 1, 2, 34, 7, 0, time.UTC)
+	if !timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", timestamp, want)
+	}
+}
+
+func TestParseFileNameWithParsers_Layout(t *testing.T) {
+	parsers, err := buildNameParsers("", "", "%Y%m%d-%H%M%S", "02-Jan-06 15:04:05")
+	if err != nil {
+		t.Fatalf("buildNameParsers error: %v", err)
+	}
+
+	channel, timestamp, err := parseFileNameWithParsers("pars-20240701-023407.audio.m4a", parsers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if channel != "pars" {
+		t.Errorf("channel = %q, want %q", channel, "pars")
+	}
+	want := time.Date(2024, time.July, 1, 2, 34, 7, 0, time.UTC)
+	if !timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", timestamp, want)
+	}
+}
+
+func TestParseFileNameWithParsers_FallsBackToDefault(t *testing.T) {
+	parsers, err := buildNameParsers(`^(?P<channel>[a-z]+)_nomatch$`, "", "", "")
+	if err != nil {
+		t.Fatalf("buildNameParsers error: %v", err)
+	}
+
+	channel, _, err := parseFileNameWithParsers("one-01-Jul-24-02:34:07.audio.m4a", parsers)
+	if err != nil {
+		t.Fatalf("expected fallback to default parser, got error: %v", err)
+	}
+	if channel != "one" {
+		t.Errorf("channel = %q, want %q", channel, "one")
+	}
+}
+
+func TestBuildNameParsers_PatternLayoutsApplyPositionally(t *testing.T) {
+	patterns := `^(?P<channel>[a-z]+)_(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2})\..+$,^(?P<channel>[a-z]+)@(?P<timestamp>\d{8}-\d{6})\..+$`
+	layouts := "2006-01-02T15-04-05,20060102-150405"
+	parsers, err := buildNameParsers(patterns, layouts, "", "02-Jan-06 15:04:05")
+	if err != nil {
+		t.Fatalf("buildNameParsers error: %v", err)
+	}
+
+	channel, timestamp, err := parseFileNameWithParsers("voa@20240701-023407.wav", parsers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if channel != "voa" {
+		t.Errorf("channel = %q, want %q", channel, "voa")
+	}
+	want := time.Date(2024, time.July, 1, 2, 34, 7, 0, time.UTC)
+	if !timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", timestamp, want)
+	}
+}
+
+func TestParseFileNameWithParsers_NoMatch(t *testing.T) {
+	parsers := []FileNameParser{defaultNameParser{}}
+
+	if _, _, err := parseFileNameWithParsers("garbage", parsers); err == nil {
+		t.Error("expected an error for an unparseable file name")
+	}
+}
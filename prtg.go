@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// connectionHealthyChannel is the "Connection Health" channel reported
+// whenever a host was reachable and gave us a file listing.
+func connectionHealthyChannel() Channel {
+	return Channel{
+		Channel:     "Connection Health",
+		Value:       "0",
+		Unit:        "Interger",
+		LimitMode:   0,
+		ValueLookup: "prtg.customlookups.gvb-sensor.timeout",
+		Warning:     "1",
+	}
+}
+
+// connectionFailureChannel is the "Connection Health" channel reported
+// whenever a host could not be reached at all.
+func connectionFailureChannel() Channel {
+	return Channel{
+		Channel:     "Connection Health",
+		Value:       "1",
+		Unit:        "Interger",
+		LimitMode:   0,
+		ValueLookup: "prtg.customlookups.gvb-sensor.timeout",
+		Warning:     "1",
+	}
+}
+
+// hostKeyMismatchChannels reports both a failed Connection Health and a
+// distinct Host Key Mismatch channel.
+func hostKeyMismatchChannels() []Channel {
+	return []Channel{
+		connectionFailureChannel(),
+		{
+			Channel:       "Host Key Mismatch",
+			Value:         "1",
+			Unit:          "custom",
+			CustomUnit:    "bool",
+			LimitMode:     1,
+			LimitErrorMax: "0",
+			LimitErrorMsg: "SSH host key did not match known_hosts or the pinned fingerprint",
+		},
+	}
+}
+
+// buildChannelReport turns a channelMap into the "Total files"/"Today Rec"
+// channels plus the gap/staleness channels.
+func buildChannelReport(channelMap map[string]*ChannelCategory, staleWarn, staleErr time.Duration, gapAnomalyFactor float64) []Channel {
+	total, today := summarizeChannels(channelMap)
+
+	var channels []Channel
+	for channel, count := range total {
+		channels = append(channels, Channel{
+			Channel:         fmt.Sprintf("%s Total files", channel),
+			LimitMode:       1,
+			LimitErrorMax:   "70",
+			LimitWarningMax: "50",
+			LimitErrorMsg:   "Too much file are stored",
+			LimitWarningMsg: "Transfering files failed",
+			Value:           fmt.Sprintf("%d", count),
+			Unit:            "custom",
+			CustomUnit:      "files",
+		})
+		channels = append(channels, Channel{
+			Channel:    fmt.Sprintf("%s Today Rec", channel),
+			LimitMode:  0,
+			Value:      fmt.Sprintf("%d", today[channel]),
+			Unit:       "custom",
+			CustomUnit: "files",
+		})
+	}
+
+	channels = append(channels, buildGapChannels(channelMap, time.Now(), staleWarn, staleErr, gapAnomalyFactor)...)
+	return channels
+}